@@ -0,0 +1,63 @@
+package json
+
+import (
+	"bytes"
+	gojson "encoding/json"
+
+	"github.com/elastic/beats/filebeat/reader"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// isJSONArray reports whether the first non-whitespace byte of content is
+// '[', which is enough to tell an array root from an object root without
+// paying for a full parse. It lets Next skip expandArray entirely for the
+// overwhelmingly common case of a plain object.
+func isJSONArray(content []byte) bool {
+	for _, b := range content {
+		switch b {
+		case ' ', '\t', '\n', '\r':
+			continue
+		default:
+			return b == '['
+		}
+	}
+	return false
+}
+
+// expandArray decodes message.Content and, if its root is a JSON array,
+// turns it into one event per element, each reusing the parent message's
+// offset and timestamp. ok is false when the content turns out not to be
+// a well-formed array after all, in which case the caller falls back to
+// the normal single-document decode.
+func (r *JSON) expandArray(message reader.Message) (events []reader.Message, ok bool) {
+	dec := gojson.NewDecoder(bytes.NewReader(message.Content))
+	dec.UseNumber()
+	root, err := decodeValue(dec)
+	if err != nil {
+		return nil, false
+	}
+
+	elements, ok := root.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	events = make([]reader.Message, 0, len(elements))
+	for _, element := range elements {
+		fields, ok := element.(map[string]interface{})
+		if !ok {
+			fields = map[string]interface{}{"value": element}
+		}
+
+		content, jsonFields, drop := r.extractMessage(fields)
+		if drop {
+			continue
+		}
+
+		event := cloneMessage(message)
+		event.Content = content
+		event.AddFields(common.MapStr{"json": jsonFields})
+		events = append(events, event)
+	}
+	return events, true
+}
@@ -0,0 +1,112 @@
+package json
+
+import (
+	"io"
+	"testing"
+
+	"github.com/elastic/beats/filebeat/reader"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// fakeReader is a minimal reader.Reader backed by a fixed slice of
+// messages, used by both array_test.go and stream_test.go.
+type fakeReader struct {
+	messages []reader.Message
+	i        int
+}
+
+func (f *fakeReader) Next() (reader.Message, error) {
+	if f.i >= len(f.messages) {
+		return reader.Message{}, io.EOF
+	}
+	m := f.messages[f.i]
+	f.i++
+	return m, nil
+}
+
+func TestIsJSONArray(t *testing.T) {
+	cases := []struct {
+		content string
+		want    bool
+	}{
+		{`[1,2,3]`, true},
+		{`  [1,2,3]`, true},
+		{`{"a":1}`, false},
+		{`  `, false},
+		{``, false},
+	}
+
+	for _, c := range cases {
+		if got := isJSONArray([]byte(c.content)); got != c.want {
+			t.Errorf("isJSONArray(%q) = %v, want %v", c.content, got, c.want)
+		}
+	}
+}
+
+func TestExpandArraysSplitsRootArray(t *testing.T) {
+	r := New(&fakeReader{messages: []reader.Message{
+		{Content: []byte(`[{"a":1},{"a":2}]`)},
+	}}, &Config{ExpandArrays: true})
+
+	first, err := r.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := first.Fields["json"].(common.MapStr)["a"]; v != int64(1) {
+		t.Fatalf("expected a=1, got %v", v)
+	}
+
+	second, err := r.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := second.Fields["json"].(common.MapStr)["a"]; v != int64(2) {
+		t.Fatalf("expected a=2, got %v", v)
+	}
+
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestExpandArraysEventsDoNotShareFieldsMap(t *testing.T) {
+	r := New(&fakeReader{messages: []reader.Message{
+		{
+			Content: []byte(`[{"a":1},{"a":2}]`),
+			Fields:  common.MapStr{"source": "upstream"},
+		},
+	}}, &Config{ExpandArrays: true})
+
+	first, err := r.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := r.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v := first.Fields["json"].(common.MapStr)["a"]; v != int64(1) {
+		t.Fatalf("expected first event's json.a to stay 1, got %v (events are sharing a Fields map)", v)
+	}
+	if v := second.Fields["json"].(common.MapStr)["a"]; v != int64(2) {
+		t.Fatalf("expected second event's json.a to be 2, got %v", v)
+	}
+	if first.Fields["source"] != "upstream" || second.Fields["source"] != "upstream" {
+		t.Fatalf("expected both events to keep the pre-existing upstream field, got %v and %v", first.Fields, second.Fields)
+	}
+}
+
+func TestExpandArraysLeavesPlainObjectsAlone(t *testing.T) {
+	r := New(&fakeReader{messages: []reader.Message{
+		{Content: []byte(`{"a":1}`)},
+	}}, &Config{ExpandArrays: true})
+
+	msg, err := r.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := msg.Fields["json"].(common.MapStr)["a"]; v != int64(1) {
+		t.Fatalf("expected a=1, got %v", v)
+	}
+}
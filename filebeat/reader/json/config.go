@@ -0,0 +1,42 @@
+package json
+
+// Config holds the options for the JSON reader.
+type Config struct {
+	MessageKey          string `config:"message_key"`
+	KeysUnderRoot       bool   `config:"keys_under_root"`
+	OverwriteKeys       bool   `config:"overwrite_keys"`
+	AddErrorKey         bool   `config:"add_error_key"`
+	IgnoreDecodingError bool   `config:"ignore_decoding_error"`
+
+	// ExpandArrays treats a message whose decoded root is a JSON array as
+	// one event per array element, instead of a single event carrying the
+	// whole array. The offset and timestamp of the parent message are
+	// reused for every element it expands to.
+	ExpandArrays bool `config:"expand_arrays"`
+
+	// StreamDocuments keeps the decoder alive across calls to Next so a
+	// file made of back-to-back JSON objects with no newline delimiter is
+	// parsed one document at a time.
+	StreamDocuments bool `config:"stream_documents"`
+
+	// Schema, if set, coerces decoded fields to the declared type.
+	Schema *SchemaConfig `config:"schema"`
+
+	// SchemaOnError is "" (attach an error, like AddErrorKey) or "drop".
+	SchemaOnError string `config:"schema_on_error"`
+
+	// KeyTransform, if set, rewrites decoded JSON keys before they are
+	// merged into the event. See KeyTransformConfig.
+	KeyTransform *KeyTransformConfig `config:"key_transform"`
+
+	// OnError controls what happens to a line that fails to decode as
+	// JSON: "log" (the default) logs it via logp.Err, same as always;
+	// "tag" attaches the error key without logging; "dead_letter" writes
+	// the raw line to DeadLetterFile and drops it from the main pipeline
+	// instead.
+	OnError string `config:"on_error"`
+
+	// DeadLetterFile is the sidecar file undecodable lines are appended
+	// to when OnError is "dead_letter".
+	DeadLetterFile string `config:"dead_letter_file"`
+}
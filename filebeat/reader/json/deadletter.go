@@ -0,0 +1,101 @@
+package json
+
+import (
+	gojson "encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// errorSink is where a line that failed to decode ends up when OnError is
+// "dead_letter". New wires the default, file-backed sink; a libbeat
+// output could implement the same interface to be plugged in instead.
+type errorSink interface {
+	record(text []byte, cause error) error
+}
+
+// deadLetterEntry is one line of the dead letter file.
+type deadLetterEntry struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error"`
+	Text  string    `json:"text"`
+}
+
+// deadLetterSink appends every failed line to a file, one JSON object per
+// line, giving operators an audit trail instead of scattered logp.Err
+// output.
+type deadLetterSink struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newDeadLetterSink(path string) *deadLetterSink {
+	return &deadLetterSink{path: path}
+}
+
+func (s *deadLetterSink) record(text []byte, cause error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("opening dead letter file '%s': %v", s.path, err)
+		}
+		s.file = f
+	}
+
+	line, err := gojson.Marshal(deadLetterEntry{
+		Time:  time.Now(),
+		Error: cause.Error(),
+		Text:  string(text),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// handleDecodeError routes a decode failure according to r.cfg.OnError.
+// It never returns an error itself: a problem writing to the dead letter
+// sink is logged and otherwise ignored, since it must not take down the
+// harvester.
+func (r *JSON) handleDecodeError(text []byte, cause error) {
+	switch {
+	case r.cfg.OnError == "dead_letter" && r.errSink != nil:
+		if err := r.errSink.record(text, cause); err != nil {
+			logp.Err("Error writing to dead letter file: %v", err)
+		}
+	case r.cfg.OnError == "tag":
+		// The caller attaches the error key via shouldTagError; nothing to log.
+	default:
+		// Also reached when OnError is "dead_letter" but errSink could not
+		// be set up (e.g. DeadLetterFile is empty), so the line is never
+		// silently lost.
+		if !r.cfg.IgnoreDecodingError {
+			logp.Err("Error decoding JSON: %v", cause)
+		}
+	}
+}
+
+// deadLettered reports whether a decode failure was actually routed to
+// the dead letter sink, and should therefore be dropped from the main
+// pipeline instead of emitted with an error attached.
+func (r *JSON) deadLettered() bool {
+	return r.cfg.OnError == "dead_letter" && r.errSink != nil
+}
+
+// shouldTagError reports whether a decode failure should have its error
+// attached under the error key. OnError: "tag" requests this by itself,
+// regardless of AddErrorKey, since tagging the error is the whole point
+// of that mode.
+func (r *JSON) shouldTagError() bool {
+	return r.cfg.AddErrorKey || r.cfg.OnError == "tag"
+}
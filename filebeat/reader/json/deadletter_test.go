@@ -0,0 +1,123 @@
+package json
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestDeadLetterSinkRecordsAppendOneJSONLinePerCall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letter.log")
+	sink := newDeadLetterSink(path)
+
+	if err := sink.record([]byte("line one"), errors.New("boom")); err != nil {
+		t.Fatalf("record returned an error: %v", err)
+	}
+	if err := sink.record([]byte("line two"), errors.New("bang")); err != nil {
+		t.Fatalf("record returned an error: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening dead letter file: %v", err)
+	}
+	defer f.Close()
+
+	var entries []deadLetterEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry deadLetterEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("entry is not valid JSON: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Text != "line one" || entries[0].Error != "boom" {
+		t.Errorf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].Text != "line two" || entries[1].Error != "bang" {
+		t.Errorf("unexpected second entry: %+v", entries[1])
+	}
+}
+
+func TestDeadLetterSinkConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letter.log")
+	sink := newDeadLetterSink(path)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := sink.record([]byte("line"), errors.New("err")); err != nil {
+				t.Errorf("record returned an error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening dead letter file: %v", err)
+	}
+	defer f.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry deadLetterEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("entry %d is not valid JSON (a concurrent write interleaved lines): %v", count, err)
+		}
+		count++
+	}
+	if count != n {
+		t.Fatalf("expected %d entries, got %d", n, count)
+	}
+}
+
+func TestDeadLetteredReportsWhetherSinkIsWired(t *testing.T) {
+	r := &JSON{cfg: &Config{OnError: "dead_letter", DeadLetterFile: "/tmp/does-not-matter"}}
+	r.errSink = newDeadLetterSink(r.cfg.DeadLetterFile)
+	if !r.deadLettered() {
+		t.Fatal("expected deadLettered to be true once errSink is wired")
+	}
+
+	r2 := &JSON{cfg: &Config{OnError: "dead_letter"}}
+	if r2.deadLettered() {
+		t.Fatal("expected deadLettered to be false when DeadLetterFile was never set")
+	}
+}
+
+func TestHandleDecodeErrorFallsBackWhenSinkMissing(t *testing.T) {
+	r := &JSON{cfg: &Config{OnError: "dead_letter", IgnoreDecodingError: true}}
+	// Must not panic even though errSink is nil: on_error is dead_letter but
+	// DeadLetterFile was never configured, so New never created a sink.
+	r.handleDecodeError([]byte("text"), errors.New("boom"))
+}
+
+func TestShouldTagErrorOnErrorTagImpliesErrorKey(t *testing.T) {
+	r := &JSON{cfg: &Config{OnError: "tag"}}
+	if !r.shouldTagError() {
+		t.Fatal("expected on_error: tag to attach the error key on its own, without add_error_key")
+	}
+
+	r2 := &JSON{cfg: &Config{}}
+	if r2.shouldTagError() {
+		t.Fatal("expected shouldTagError to be false with neither add_error_key nor on_error: tag set")
+	}
+
+	r3 := &JSON{cfg: &Config{AddErrorKey: true}}
+	if !r3.shouldTagError() {
+		t.Fatal("expected add_error_key alone to still attach the error key")
+	}
+}
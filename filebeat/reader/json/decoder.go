@@ -0,0 +1,94 @@
+package json
+
+import (
+	"bytes"
+	gojson "encoding/json"
+	"fmt"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/jsontransform"
+)
+
+// unmarshal is equivalent to json.Unmarshal but it converts numbers to
+// int64 where possible, instead of always using float64.
+func unmarshal(text []byte, fields *map[string]interface{}) error {
+	dec := gojson.NewDecoder(bytes.NewReader(text))
+	dec.UseNumber()
+	if err := dec.Decode(fields); err != nil {
+		return err
+	}
+	jsontransform.TransformNumbers(*fields)
+	return nil
+}
+
+// decodeObject reads object members up to, and including, the closing
+// '}'. The opening '{' must already have been consumed.
+func decodeObject(dec *gojson.Decoder) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := keyTok.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected an object key, got %v", keyTok)
+		}
+
+		value, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = value
+	}
+	if _, err := dec.Token(); err != nil { // consume '}'
+		return nil, err
+	}
+	return fields, nil
+}
+
+// decodeArray reads array elements up to, and including, the closing ']'.
+// The opening '[' must already have been consumed.
+func decodeArray(dec *gojson.Decoder) ([]interface{}, error) {
+	var values []interface{}
+	for dec.More() {
+		value, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	if _, err := dec.Token(); err != nil { // consume ']'
+		return nil, err
+	}
+	return values, nil
+}
+
+func decodeValue(dec *gojson.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := tok.(type) {
+	case gojson.Delim:
+		switch v {
+		case '{':
+			return decodeObject(dec)
+		case '[':
+			return decodeArray(dec)
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", v)
+	case gojson.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	default:
+		return v, nil
+	}
+}
+
+func createJSONError(message string) common.MapStr {
+	return common.MapStr{"message": message, "type": "json"}
+}
@@ -1,7 +1,6 @@
 package json
 
 import (
-	"bytes"
 	gojson "encoding/json"
 	"fmt"
 	"time"
@@ -10,37 +9,78 @@ import (
 	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/common/jsontransform"
-	"github.com/elastic/beats/libbeat/logp"
 )
 
 type JSON struct {
-	reader reader.Reader
-	cfg    *Config
+	reader  reader.Reader
+	cfg     *Config
+	errSink errorSink
+
+	// pending holds events produced by ExpandArrays or StreamDocuments
+	// that are still waiting to be returned from Next.
+	pending []reader.Message
+
+	// streamDec and streamMsg keep the state of an in-progress
+	// StreamDocuments read alive across calls to Next.
+	streamDec *gojson.Decoder
+	streamMsg reader.Message
 }
 
 // NewJSONReader creates a new reader that can decode JSON.
 func New(r reader.Reader, cfg *Config) *JSON {
-	return &JSON{reader: r, cfg: cfg}
+	j := &JSON{reader: r, cfg: cfg}
+	if cfg.OnError == "dead_letter" && cfg.DeadLetterFile != "" {
+		j.errSink = newDeadLetterSink(cfg.DeadLetterFile)
+	}
+	return j
 }
 
-// decodeJSON unmarshals the text parameter into a MapStr and
-// returns the new text column if one was requested.
-func (r *JSON) decode(text []byte) ([]byte, common.MapStr) {
+// decodeJSON unmarshals the text parameter into a MapStr and returns the
+// new text column if one was requested, plus whether the event should be
+// dropped because it failed Schema validation with SchemaOnError: "drop".
+func (r *JSON) decode(text []byte) ([]byte, common.MapStr, bool) {
 	var jsonFields map[string]interface{}
-
 	err := unmarshal(text, &jsonFields)
 	if err != nil || jsonFields == nil {
-		if !r.cfg.IgnoreDecodingError {
-			logp.Err("Error decoding JSON: %v", err)
-		}
-		if r.cfg.AddErrorKey {
+		r.handleDecodeError(text, err)
+		if r.shouldTagError() {
 			jsonFields = common.MapStr{"error": createJSONError(fmt.Sprintf("Error decoding JSON: %v", err))}
 		}
-		return text, jsonFields
+		return text, jsonFields, r.deadLettered()
+	}
+
+	return r.extractMessage(jsonFields)
+}
+
+// cloneMessage copies message, giving the copy its own Fields map instead
+// of sharing the original's. Callers that turn one message into several
+// events (ExpandArrays, StreamDocuments) must start from a clone before
+// calling AddFields on each one, since AddFields mutates an existing
+// Fields map in place and a plain struct copy would still share it.
+func cloneMessage(message reader.Message) reader.Message {
+	clone := message
+	if message.Fields != nil {
+		clone.Fields = make(common.MapStr, len(message.Fields))
+		for k, v := range message.Fields {
+			clone.Fields[k] = v
+		}
+	}
+	return clone
+}
+
+// extractMessage applies Schema validation and pulls the configured
+// MessageKey out of an already decoded set of fields, in the same way
+// decode does once it has a document. It is also used by ExpandArrays
+// and StreamDocuments, which decode their own documents and only need
+// this last step.
+func (r *JSON) extractMessage(jsonFields map[string]interface{}) ([]byte, common.MapStr, bool) {
+	jsonFields, drop := r.applySchema(jsonFields)
+	if drop {
+		return []byte(""), jsonFields, true
 	}
 
 	if len(r.cfg.MessageKey) == 0 {
-		return []byte(""), jsonFields
+		return []byte(""), jsonFields, false
 	}
 
 	textValue, ok := jsonFields[r.cfg.MessageKey]
@@ -48,7 +88,7 @@ func (r *JSON) decode(text []byte) ([]byte, common.MapStr) {
 		if r.cfg.AddErrorKey {
 			jsonFields["error"] = createJSONError(fmt.Sprintf("Key '%s' not found", r.cfg.MessageKey))
 		}
-		return []byte(""), jsonFields
+		return []byte(""), jsonFields, false
 	}
 
 	textString, ok := textValue.(string)
@@ -56,40 +96,58 @@ func (r *JSON) decode(text []byte) ([]byte, common.MapStr) {
 		if r.cfg.AddErrorKey {
 			jsonFields["error"] = createJSONError(fmt.Sprintf("Value of key '%s' is not a string", r.cfg.MessageKey))
 		}
-		return []byte(""), jsonFields
+		return []byte(""), jsonFields, false
 	}
 
-	return []byte(textString), jsonFields
-}
-
-// unmarshal is equivalent with json.Unmarshal but it converts numbers
-// to int64 where possible, instead of using always float64.
-func unmarshal(text []byte, fields *map[string]interface{}) error {
-	dec := gojson.NewDecoder(bytes.NewReader(text))
-	dec.UseNumber()
-	err := dec.Decode(fields)
-	if err != nil {
-		return err
-	}
-	jsontransform.TransformNumbers(*fields)
-	return nil
+	return []byte(textString), jsonFields, false
 }
 
-// Next decodes JSON and returns the filled Line object.
+// Next decodes JSON and returns the filled Line object. When ExpandArrays
+// or StreamDocuments is configured, a single call to the underlying
+// reader can yield more than one event; the extra ones are queued in
+// pending and drained before reading again. Events dropped by Schema
+// validation are skipped and Next moves on to the following one.
 func (r *JSON) Next() (reader.Message, error) {
-	message, err := r.reader.Next()
-	if err != nil {
-		return message, err
-	}
+	for {
+		if len(r.pending) > 0 {
+			message := r.pending[0]
+			r.pending = r.pending[1:]
+			return message, nil
+		}
 
-	var fields common.MapStr
-	message.Content, fields = r.decode(message.Content)
-	message.AddFields(common.MapStr{"json": fields})
-	return message, nil
-}
+		if r.cfg.StreamDocuments {
+			message, drop, err := r.nextDocument()
+			if err != nil || !drop {
+				return message, err
+			}
+			continue
+		}
+
+		message, err := r.reader.Next()
+		if err != nil {
+			return message, err
+		}
+
+		if r.cfg.ExpandArrays && isJSONArray(message.Content) {
+			if events, ok := r.expandArray(message); ok {
+				if len(events) == 0 {
+					continue
+				}
+				message = events[0]
+				r.pending = events[1:]
+				return message, nil
+			}
+		}
 
-func createJSONError(message string) common.MapStr {
-	return common.MapStr{"message": message, "type": "json"}
+		var fields common.MapStr
+		var drop bool
+		message.Content, fields, drop = r.decode(message.Content)
+		if drop {
+			continue
+		}
+		message.AddFields(common.MapStr{"json": fields})
+		return message, nil
+	}
 }
 
 // MergeJSONFields writes the JSON fields in the event map,
@@ -97,6 +155,8 @@ func createJSONError(message string) common.MapStr {
 // If MessageKey is defined, the Text value from the event always
 // takes precedence.
 func MergeJSONFields(data common.MapStr, jsonFields common.MapStr, text *string, config Config) time.Time {
+	jsonFields = transformKeys(jsonFields, config.KeyTransform)
+
 	// The message key might have been modified by multiline
 	if len(config.MessageKey) > 0 && text != nil {
 		jsonFields[config.MessageKey] = *text
@@ -0,0 +1,147 @@
+package json
+
+import (
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// KeyTransformConfig controls how MergeJSONFields rewrites decoded keys.
+// Flatten runs first, then Dedot and Case apply to every resulting key.
+type KeyTransformConfig struct {
+	// Dedot replaces '.' with '_' in every key.
+	Dedot bool `config:"dedot"`
+
+	// Flatten joins nested objects into a flat map, keys joined with
+	// Separator (default ".").
+	Flatten   bool   `config:"flatten"`
+	Separator string `config:"separator"`
+
+	// Case is "snake", "camel", "lower" or "" (unchanged).
+	Case string `config:"case"`
+}
+
+// transformKeys rewrites jsonFields according to cfg, recursing into
+// nested objects and arrays.
+func transformKeys(jsonFields common.MapStr, cfg *KeyTransformConfig) common.MapStr {
+	if cfg == nil {
+		return jsonFields
+	}
+
+	fields := map[string]interface{}(jsonFields)
+	if cfg.Flatten {
+		fields = flattenFields("", fields, flattenSeparator(cfg))
+	}
+
+	return common.MapStr(transformFieldKeys(fields, cfg))
+}
+
+func flattenSeparator(cfg *KeyTransformConfig) string {
+	if cfg.Separator == "" {
+		return "."
+	}
+	return cfg.Separator
+}
+
+// flattenFields joins nested objects into a single-level map, joining the
+// parent and child key with sep. Arrays are preserved, flattening any
+// object they contain independently.
+func flattenFields(prefix string, fields map[string]interface{}, sep string) map[string]interface{} {
+	flat := map[string]interface{}{}
+	for key, value := range fields {
+		full := key
+		if prefix != "" {
+			full = prefix + sep + key
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for fk, fv := range flattenFields(full, v, sep) {
+				flat[fk] = fv
+			}
+		case []interface{}:
+			flat[full] = flattenArray(v, sep)
+		default:
+			flat[full] = value
+		}
+	}
+	return flat
+}
+
+func flattenArray(values []interface{}, sep string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, value := range values {
+		if m, ok := value.(map[string]interface{}); ok {
+			out[i] = flattenFields("", m, sep)
+		} else {
+			out[i] = value
+		}
+	}
+	return out
+}
+
+// transformFieldKeys applies Dedot/Case to every key, recursing into
+// nested objects and arrays of objects.
+func transformFieldKeys(fields map[string]interface{}, cfg *KeyTransformConfig) map[string]interface{} {
+	out := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		out[transformKey(key, cfg)] = transformFieldValue(value, cfg)
+	}
+	return out
+}
+
+func transformFieldValue(value interface{}, cfg *KeyTransformConfig) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return transformFieldKeys(v, cfg)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = transformFieldValue(elem, cfg)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+func transformKey(key string, cfg *KeyTransformConfig) string {
+	if cfg.Dedot {
+		key = strings.Replace(key, ".", "_", -1)
+	}
+
+	switch cfg.Case {
+	case "snake":
+		key = toSnakeCase(key)
+	case "camel":
+		key = toCamelCase(key)
+	case "lower":
+		key = strings.ToLower(key)
+	}
+	return key
+}
+
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r - 'A' + 'a')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func toCamelCase(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '_' || r == '-'
+	})
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.Title(parts[i])
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,93 @@
+package json
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestTransformKeysNilConfigIsNoop(t *testing.T) {
+	in := common.MapStr{"a.b": 1}
+	out := transformKeys(in, nil)
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("expected no-op, got %v", out)
+	}
+}
+
+func TestTransformKeysDedot(t *testing.T) {
+	in := common.MapStr{"a.b": 1}
+	out := transformKeys(in, &KeyTransformConfig{Dedot: true})
+	if _, ok := out["a_b"]; !ok {
+		t.Fatalf("expected a.b to become a_b, got %v", out)
+	}
+}
+
+func TestTransformKeysCase(t *testing.T) {
+	cases := []struct {
+		caseMode string
+		key      string
+		want     string
+	}{
+		{"snake", "fooBar", "foo_bar"},
+		{"camel", "foo_bar", "fooBar"},
+		{"lower", "FooBar", "foobar"},
+	}
+
+	for _, c := range cases {
+		in := common.MapStr{c.key: 1}
+		out := transformKeys(in, &KeyTransformConfig{Case: c.caseMode})
+		if _, ok := out[c.want]; !ok {
+			t.Errorf("case %q: transformKeys(%v) = %v, want key %q", c.caseMode, in, out, c.want)
+		}
+	}
+}
+
+func TestTransformKeysFlattenNestedObject(t *testing.T) {
+	in := common.MapStr{
+		"user": map[string]interface{}{
+			"id":   5,
+			"name": "a",
+		},
+	}
+
+	out := transformKeys(in, &KeyTransformConfig{Flatten: true})
+	if out["user.id"] != 5 || out["user.name"] != "a" {
+		t.Fatalf("expected flattened keys, got %v", out)
+	}
+	if _, ok := out["user"]; ok {
+		t.Fatalf("expected the nested 'user' key to be gone, got %v", out)
+	}
+}
+
+func TestTransformKeysFlattenCustomSeparator(t *testing.T) {
+	in := common.MapStr{
+		"user": map[string]interface{}{"id": 5},
+	}
+
+	out := transformKeys(in, &KeyTransformConfig{Flatten: true, Separator: "_"})
+	if out["user_id"] != 5 {
+		t.Fatalf("expected user_id, got %v", out)
+	}
+}
+
+func TestTransformKeysFlattenArrayOfObjects(t *testing.T) {
+	in := common.MapStr{
+		"items": []interface{}{
+			map[string]interface{}{"a.b": 1},
+		},
+	}
+
+	out := transformKeys(in, &KeyTransformConfig{Flatten: true, Dedot: true})
+	items, ok := out["items"].([]interface{})
+	if !ok || len(items) != 1 {
+		t.Fatalf("expected items to stay an array of one element, got %v", out["items"])
+	}
+	elem, ok := items[0].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected element to be a map, got %v", items[0])
+	}
+	if _, ok := elem["a_b"]; !ok {
+		t.Fatalf("expected nested array element key to be dedotted, got %v", elem)
+	}
+}
@@ -0,0 +1,131 @@
+package json
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// SchemaConfig maps a dotted field path (e.g. "user.id") to the type its
+// value should be coerced to: "long", "double", "boolean", "string" or
+// "date" (RFC3339). This is a field-type map rather than real JSON Schema
+// (draft-07) validation; draft-07 support was scoped out.
+type SchemaConfig struct {
+	Fields map[string]string `config:"fields"`
+}
+
+// applySchema coerces fields to the types declared in r.cfg.Schema and
+// reports whether the event should be dropped.
+func (r *JSON) applySchema(fields map[string]interface{}) (map[string]interface{}, bool) {
+	if r.cfg.Schema == nil {
+		return fields, false
+	}
+
+	var errs []string
+	for path, typ := range r.cfg.Schema.Fields {
+		value, ok := getField(fields, path)
+		if !ok {
+			errs = append(errs, fmt.Sprintf("field '%s' is missing", path))
+			continue
+		}
+
+		coerced, err := coerceType(value, typ)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("field '%s': %v", path, err))
+			continue
+		}
+		setField(fields, path, coerced)
+	}
+
+	if len(errs) == 0 {
+		return fields, false
+	}
+
+	message := fmt.Sprintf("JSON schema validation failed: %s", strings.Join(errs, "; "))
+	if !r.cfg.IgnoreDecodingError {
+		logp.Err(message)
+	}
+	if r.cfg.AddErrorKey {
+		fields["error"] = createJSONError(message)
+	}
+	return fields, r.cfg.SchemaOnError == "drop"
+}
+
+func coerceType(value interface{}, typ string) (interface{}, error) {
+	switch typ {
+	case "long":
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			return int64(v), nil
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		}
+	case "double":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		case string:
+			return strconv.ParseFloat(v, 64)
+		}
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+	case "string":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprintf("%v", value), nil
+	case "date":
+		switch v := value.(type) {
+		case string:
+			return time.Parse(time.RFC3339, v)
+		case time.Time:
+			return v, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown schema type %q", typ)
+	}
+	return nil, fmt.Errorf("cannot coerce %T to %s", value, typ)
+}
+
+// getField and setField resolve a dotted path against a decoded
+// document's nested maps.
+func getField(fields map[string]interface{}, path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	current := interface{}(fields)
+	for _, part := range parts {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func setField(fields map[string]interface{}, path string, value interface{}) {
+	parts := strings.Split(path, ".")
+	m := fields
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = value
+}
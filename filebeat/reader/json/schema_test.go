@@ -0,0 +1,114 @@
+package json
+
+import "testing"
+
+func TestCoerceType(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		typ   string
+		want  interface{}
+	}{
+		{int64(3), "long", int64(3)},
+		{float64(3), "long", int64(3)},
+		{"3", "long", int64(3)},
+		{int64(3), "double", float64(3)},
+		{"3.5", "double", float64(3.5)},
+		{"true", "boolean", true},
+		{42, "string", "42"},
+	}
+
+	for _, c := range cases {
+		got, err := coerceType(c.value, c.typ)
+		if err != nil {
+			t.Errorf("coerceType(%v, %q) returned error: %v", c.value, c.typ, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("coerceType(%v, %q) = %v, want %v", c.value, c.typ, got, c.want)
+		}
+	}
+}
+
+func TestCoerceTypeErrors(t *testing.T) {
+	if _, err := coerceType("not-a-number", "long"); err == nil {
+		t.Error("expected an error coercing a non-numeric string to long")
+	}
+	if _, err := coerceType("x", "unknown"); err == nil {
+		t.Error("expected an error for an unknown schema type")
+	}
+}
+
+func TestGetSetField(t *testing.T) {
+	fields := map[string]interface{}{
+		"user": map[string]interface{}{
+			"id": "5",
+		},
+	}
+
+	value, ok := getField(fields, "user.id")
+	if !ok || value != "5" {
+		t.Fatalf("getField(user.id) = %v, %v", value, ok)
+	}
+
+	if _, ok := getField(fields, "user.missing"); ok {
+		t.Fatal("expected getField to report missing nested field")
+	}
+	if _, ok := getField(fields, "missing"); ok {
+		t.Fatal("expected getField to report missing top-level field")
+	}
+
+	setField(fields, "user.id", int64(5))
+	if v, _ := getField(fields, "user.id"); v != int64(5) {
+		t.Fatalf("setField did not update user.id, got %v", v)
+	}
+}
+
+func TestApplySchemaCoercesAndFlagsMissingFields(t *testing.T) {
+	r := &JSON{cfg: &Config{
+		Schema: &SchemaConfig{Fields: map[string]string{
+			"id":      "long",
+			"missing": "long",
+		}},
+		IgnoreDecodingError: true,
+		AddErrorKey:         true,
+	}}
+
+	fields, drop := r.applySchema(map[string]interface{}{"id": "5"})
+	if drop {
+		t.Fatal("expected applySchema not to drop without schema_on_error: drop")
+	}
+	if fields["id"] != int64(5) {
+		t.Fatalf("expected id to be coerced to int64(5), got %v (%T)", fields["id"], fields["id"])
+	}
+	if _, ok := fields["error"]; !ok {
+		t.Fatal("expected an error key describing the missing field")
+	}
+}
+
+func TestApplySchemaOnErrorDrop(t *testing.T) {
+	r := &JSON{cfg: &Config{
+		Schema: &SchemaConfig{Fields: map[string]string{
+			"id": "long",
+		}},
+		SchemaOnError:       "drop",
+		IgnoreDecodingError: true,
+	}}
+
+	_, drop := r.applySchema(map[string]interface{}{"id": "not-a-number"})
+	if !drop {
+		t.Fatal("expected applySchema to drop the event on a coercion failure")
+	}
+}
+
+func TestApplySchemaNoop(t *testing.T) {
+	r := &JSON{cfg: &Config{}}
+
+	in := map[string]interface{}{"id": "5"}
+	fields, drop := r.applySchema(in)
+	if drop {
+		t.Fatal("expected applySchema to be a no-op without a configured schema")
+	}
+	if fields["id"] != "5" {
+		t.Fatalf("expected fields to be unchanged, got %v", fields)
+	}
+}
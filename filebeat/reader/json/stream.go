@@ -0,0 +1,54 @@
+package json
+
+import (
+	"bytes"
+	gojson "encoding/json"
+	"fmt"
+
+	"github.com/elastic/beats/filebeat/reader"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// nextDocument implements Next when StreamDocuments is enabled. It keeps a
+// json.Decoder alive across calls so a message made of several
+// back-to-back JSON objects, with no newline between them, is decoded one
+// document at a time instead of failing on the trailing bytes. The bool
+// return reports whether the caller should drop this event and move on
+// to the next one (see Schema/SchemaOnError).
+func (r *JSON) nextDocument() (reader.Message, bool, error) {
+	if r.streamDec == nil || !r.streamDec.More() {
+		message, err := r.reader.Next()
+		if err != nil {
+			return message, false, err
+		}
+		r.streamDec = gojson.NewDecoder(bytes.NewReader(message.Content))
+		r.streamDec.UseNumber()
+		r.streamMsg = message
+	}
+
+	event := cloneMessage(r.streamMsg)
+	startOffset := r.streamDec.InputOffset()
+	root, err := decodeValue(r.streamDec)
+	if err != nil {
+		r.streamDec = nil
+		// Only the part of the line starting at this document matters:
+		// earlier documents on the same line already decoded fine.
+		r.handleDecodeError(event.Content[startOffset:], err)
+		var fields common.MapStr
+		if r.shouldTagError() {
+			fields = common.MapStr{"error": createJSONError(fmt.Sprintf("Error decoding JSON: %v", err))}
+		}
+		event.AddFields(common.MapStr{"json": fields})
+		return event, r.deadLettered(), nil
+	}
+
+	fields, ok := root.(map[string]interface{})
+	if !ok {
+		fields = map[string]interface{}{"value": root}
+	}
+
+	content, jsonFields, drop := r.extractMessage(fields)
+	event.Content = content
+	event.AddFields(common.MapStr{"json": jsonFields})
+	return event, drop, nil
+}
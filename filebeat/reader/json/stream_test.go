@@ -0,0 +1,85 @@
+package json
+
+import (
+	"io"
+	"testing"
+
+	"github.com/elastic/beats/filebeat/reader"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestStreamDocumentsSplitsConcatenatedObjects(t *testing.T) {
+	r := New(&fakeReader{messages: []reader.Message{
+		{Content: []byte(`{"a":1}{"a":2}`)},
+	}}, &Config{StreamDocuments: true})
+
+	first, err := r.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := first.Fields["json"].(common.MapStr)["a"]; v != int64(1) {
+		t.Fatalf("expected a=1, got %v", v)
+	}
+
+	second, err := r.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := second.Fields["json"].(common.MapStr)["a"]; v != int64(2) {
+		t.Fatalf("expected a=2, got %v", v)
+	}
+
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestStreamDocumentsEventsDoNotShareFieldsMap(t *testing.T) {
+	r := New(&fakeReader{messages: []reader.Message{
+		{
+			Content: []byte(`{"a":1}{"a":2}`),
+			Fields:  common.MapStr{"source": "upstream"},
+		},
+	}}, &Config{StreamDocuments: true})
+
+	first, err := r.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := r.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v := first.Fields["json"].(common.MapStr)["a"]; v != int64(1) {
+		t.Fatalf("expected first event's json.a to stay 1, got %v (events are sharing a Fields map)", v)
+	}
+	if v := second.Fields["json"].(common.MapStr)["a"]; v != int64(2) {
+		t.Fatalf("expected second event's json.a to be 2, got %v", v)
+	}
+	if first.Fields["source"] != "upstream" || second.Fields["source"] != "upstream" {
+		t.Fatalf("expected both events to keep the pre-existing upstream field, got %v and %v", first.Fields, second.Fields)
+	}
+}
+
+func TestStreamDocumentsRecordsOffsetOfFailingFragment(t *testing.T) {
+	r := New(&fakeReader{messages: []reader.Message{
+		{Content: []byte(`{"a":1}not-json`)},
+	}}, &Config{StreamDocuments: true, IgnoreDecodingError: true, AddErrorKey: true})
+
+	first, err := r.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := first.Fields["json"].(common.MapStr)["a"]; v != int64(1) {
+		t.Fatalf("expected a=1, got %v", v)
+	}
+
+	second, err := r.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := second.Fields["json"].(common.MapStr)["error"]; !ok {
+		t.Fatalf("expected an error field, got %v", second.Fields["json"])
+	}
+}